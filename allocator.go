@@ -0,0 +1,19 @@
+package bpool
+
+// Allocator manages the backing storage for pooled buffers. Implementing it
+// with a cgo malloc/free wrapper lets large, long-lived buffers live off
+// the Go heap and out of GC scan; the default implementation just uses the
+// Go heap.
+type Allocator interface {
+	// Alloc returns a slice of length 0 and capacity at least n.
+	Alloc(n int) []byte
+	// Free releases a slice previously returned by Alloc. Implementations
+	// backed by the Go heap may treat this as a no-op.
+	Free(buf []byte)
+}
+
+// heapAllocator is the default Allocator, backed by the Go heap.
+type heapAllocator struct{}
+
+func (heapAllocator) Alloc(n int) []byte { return make([]byte, 0, n) }
+func (heapAllocator) Free(buf []byte)    {}
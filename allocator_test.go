@@ -0,0 +1,89 @@
+package bpool
+
+import (
+	"sync"
+	"testing"
+)
+
+// countingAllocator tracks every Alloc/Free call so tests can assert that
+// growth and Destroy actually release backing arrays instead of leaking
+// them.
+type countingAllocator struct {
+	mu     sync.Mutex
+	allocs int
+	frees  int
+}
+
+func (a *countingAllocator) Alloc(n int) []byte {
+	a.mu.Lock()
+	a.allocs++
+	a.mu.Unlock()
+	return make([]byte, 0, n)
+}
+
+func (a *countingAllocator) Free(buf []byte) {
+	a.mu.Lock()
+	a.frees++
+	a.mu.Unlock()
+}
+
+// TestGrowByRetiresFreedOnPut grows a buffer past its original capacity
+// several times, which retires its superseded backing arrays (see
+// buffer.growBy), and checks Put's Reset frees every one of them rather
+// than leaking them.
+func TestGrowByRetiresFreedOnPut(t *testing.T) {
+	alloc := &countingAllocator{}
+	pool := NewBufferPool(64<<20, &Options{Allocator: alloc})
+	defer pool.Done()
+
+	buf := pool.Get(baseline0)
+	for i := 0; i < 5; i++ {
+		if _, err := buf.Extend(baseline0); err != nil {
+			t.Fatalf("Extend: %v", err)
+		}
+	}
+
+	alloc.mu.Lock()
+	allocs := alloc.allocs
+	frees := alloc.frees
+	alloc.mu.Unlock()
+	if allocs == 0 {
+		t.Fatal("expected at least one grow-time allocation")
+	}
+	if frees != 0 {
+		t.Fatalf("got %d frees before Put, want 0 (arrays still aliased by Bytes())", frees)
+	}
+
+	pool.Put(buf)
+
+	// Every allocated array except the last has since been replaced by a
+	// growth and retired; the last is still the buffer's live backing array,
+	// so it isn't freed until the buffer itself is discarded.
+	wantFrees := allocs - 1
+
+	alloc.mu.Lock()
+	defer alloc.mu.Unlock()
+	if alloc.frees != wantFrees {
+		t.Fatalf("frees=%d after Put, want %d (one per retired array)", alloc.frees, wantFrees)
+	}
+}
+
+// TestDestroyFreesBucketedBuffers checks Destroy releases the backing array
+// of every buffer still sitting in a bucket (or overflow bucket) when the
+// pool is torn down.
+func TestDestroyFreesBucketedBuffers(t *testing.T) {
+	alloc := &countingAllocator{}
+	pool := NewBufferPool(64<<20, &Options{Allocator: alloc})
+
+	for _, n := range []int64{baseline0, baseline0 << 3, baseline0 << (bucketTiers + 2)} {
+		pool.Put(pool.Get(n))
+	}
+
+	pool.Destroy()
+
+	alloc.mu.Lock()
+	defer alloc.mu.Unlock()
+	if alloc.frees != alloc.allocs {
+		t.Fatalf("frees=%d after Destroy, want %d (one per allocated buffer)", alloc.frees, alloc.allocs)
+	}
+}
@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"math/rand"
 	"sync"
+	"sync/atomic"
 	"time"
 )
 
@@ -12,6 +13,20 @@ const (
 	// maxBufferSize value to limit maximum memory for the buffer.
 	maxBufferSize = (int64(1) << 34) - 1
 
+	// baseline0 is the capacity of the smallest bucket managed by a BufferPool.
+	baseline0 = int64(1) << 10 // 1KiB
+
+	// bucketTiers is the number of buckets above baseline0, each double the
+	// capacity of the one before it. Requests bigger than
+	// baseline0<<bucketTiers are served from overflow buckets instead, one
+	// per power-of-two size class.
+	bucketTiers = 5
+
+	// DefaultMinAlloc is the smallest capacity a Get will ever request,
+	// floored at the smallest bucket baseline so a flurry of tiny writes
+	// doesn't each pay for a distinct undersized allocation.
+	DefaultMinAlloc = baseline0
+
 	// DefaultInitialInterval duration for waiting in the queue due to system memory surge operations
 	DefaultInitialInterval = 500 * time.Millisecond
 	// DefaultRandomizationFactor sets factor to backoff when buffer pool reaches target size
@@ -32,33 +47,218 @@ type Buffer struct {
 
 // NewBuffer returns buffer and initializes it using buf as its initial content.
 func (pool *BufferPool) NewBuffer(buf []byte) *Buffer {
-	return &Buffer{cap: pool.cap, internal: buffer{buf: buf, size: int64(len(buf))}}
+	return &Buffer{cap: pool.cap, internal: buffer{buf: buf, size: int64(len(buf)), alloc: pool.allocator}}
+}
+
+// bucketCounters tracks allocation statistics for a single bucket, all
+// updated with atomic ops since Get/Put run concurrently across goroutines.
+type bucketCounters struct {
+	get, put, miss, less, equal, greater, half int32
+}
+
+// overflowBucket is a size class for requests bigger than the largest
+// configured tier, created lazily and keyed by its exact capacity (see
+// nextPowerOfTwo), so unrelated "large" sizes don't thrash a single shared
+// bucket the way one catch-all channel would.
+type overflowBucket struct {
+	ch       chan *Buffer
+	counters bucketCounters
+}
+
+// nextPowerOfTwo returns the smallest power of two >= n.
+func nextPowerOfTwo(n int64) int64 {
+	if n <= 1 {
+		return 1
+	}
+	n--
+	n |= n >> 1
+	n |= n >> 2
+	n |= n >> 4
+	n |= n >> 8
+	n |= n >> 16
+	n |= n >> 32
+	return n + 1
+}
+
+// Stats reports the allocation counters for a single bucket, returned from
+// BufferPool.Stats keyed by the bucket's baseline capacity.
+type Stats struct {
+	// Baseline is the bucket's capacity, in bytes.
+	Baseline int64
+	// Get is the number of Get calls served by this bucket.
+	Get int64
+	// Put is the number of buffers handed back to this bucket, whether by Put
+	// or by a Get that set a checked-out buffer aside; buffers dropped
+	// because the bucket was full are counted too.
+	Put int64
+	// Miss is the number of Get calls that found the bucket's channel empty
+	// and allocated a fresh buffer instead.
+	Miss int64
+	// Less is the number of Get calls where the checked-out buffer was
+	// smaller than requested. A bucket allocates at its own capacity, but
+	// Put files a buffer that grew past its original capacity under the
+	// next bucket (or size class) up, where it can be smaller than a later
+	// request.
+	Less int64
+	// Equal is the number of Get calls served with a buffer of exactly the
+	// requested capacity.
+	Equal int64
+	// Greater is the number of Get calls where the checked-out buffer was
+	// more than double the requested size and was released back to the pool
+	// in favor of a freshly allocated, right-sized buffer.
+	Greater int64
+	// Half is the number of Get calls served with a buffer bigger than
+	// requested but not more than double (a reasonable reuse).
+	Half int64
+}
+
+// bucketIndex returns the index of the smallest regular bucket whose
+// baseline capacity is >= n. Callers must first check n against the
+// largest tier; sizes beyond it belong in an overflow bucket instead.
+func (pool *BufferPool) bucketIndex(n int64) int {
+	for i, baseline := range pool.baseline {
+		if n <= baseline {
+			return i
+		}
+	}
+	return len(pool.baseline) - 1
+}
+
+// overflowBucketFor returns the overflow bucket for a given size class,
+// creating it on first use.
+func (pool *BufferPool) overflowBucketFor(class int64) *overflowBucket {
+	if v, ok := pool.overflow.Load(class); ok {
+		return v.(*overflowBucket)
+	}
+	ob := &overflowBucket{ch: make(chan *Buffer, pool.chanSize)}
+	actual, _ := pool.overflow.LoadOrStore(class, ob)
+	return actual.(*overflowBucket)
+}
+
+// allocCap allocates a fresh buffer with an internal capacity of exactly
+// capacity bytes.
+func (pool *BufferPool) allocCap(capacity int64) *Buffer {
+	return &Buffer{cap: pool.cap, internal: buffer{buf: pool.allocator.Alloc(int(capacity)), alloc: pool.allocator}}
+}
+
+// Get returns a buffer from the bucket best suited for n, allocating a new
+// one if the bucket's channel is empty. The returned buffer always has
+// cap(buf.Internal()) >= n. If the checked-out buffer is more than double
+// the requested size, it is returned to its bucket and a right-sized buffer
+// is allocated in its place, so oversized buffers don't linger in a caller's
+// hands.
+func (pool *BufferPool) Get(n int64) *Buffer {
+	buf, _ := pool.get(n, func() error {
+		pool.Backoff()
+		return nil
+	})
+	return buf
+}
+
+// get is the shared implementation behind Get and GetContext. backoff is
+// only invoked, and only able to fail, on a bucket miss.
+func (pool *BufferPool) get(n int64, backoff func() error) (*Buffer, error) {
+	if n < pool.minAlloc {
+		n = pool.minAlloc
+	}
+	if top := pool.baseline[len(pool.baseline)-1]; n > top {
+		class := nextPowerOfTwo(n)
+		ob := pool.overflowBucketFor(class)
+		return pool.serveFromBucket(ob.ch, &ob.counters, n, class, backoff)
+	}
+	idx := pool.bucketIndex(n)
+	return pool.serveFromBucket(pool.buckets[idx], &pool.counters[idx], n, pool.baseline[idx], backoff)
+}
+
+// serveFromBucket tries to satisfy a request for n bytes from ch, a bucket
+// that allocates its buffers at capacity bytes, falling back to a
+// fresh allocation on a miss or on a capacity mismatch.
+func (pool *BufferPool) serveFromBucket(ch chan *Buffer, c *bucketCounters, n, capacity int64, backoff func() error) (*Buffer, error) {
+	select {
+	case buf := <-ch:
+		atomic.AddInt32(&c.get, 1)
+		switch bufCap := int64(cap(buf.internal.buf)); {
+		case bufCap < n:
+			// A buffer that grew past its original capacity before being
+			// Put back can be smaller than n; set it aside rather than
+			// break the cap(buf) >= n contract.
+			atomic.AddInt32(&c.less, 1)
+			pool.returnTo(ch, c, buf)
+			return pool.allocCap(capacity), nil
+		case bufCap == n:
+			atomic.AddInt32(&c.equal, 1)
+		case bufCap > n*2:
+			atomic.AddInt32(&c.greater, 1)
+			pool.returnTo(ch, c, buf)
+			return pool.allocCap(capacity), nil
+		default:
+			atomic.AddInt32(&c.half, 1)
+		}
+		return buf, nil
+	default:
+		atomic.AddInt32(&c.miss, 1)
+		if err := backoff(); err != nil {
+			return nil, err
+		}
+		return pool.allocCap(capacity), nil
+	}
 }
 
-// Get returns buffer if any in the pool or creates a new buffer
-func (pool *BufferPool) Get() (buf *Buffer) {
-	t := pool.cap.NewTicker()
+// returnTo puts buf back onto ch, dropping it if the bucket is full.
+func (pool *BufferPool) returnTo(ch chan *Buffer, c *bucketCounters, buf *Buffer) {
+	atomic.AddInt32(&c.put, 1)
 	select {
-	case buf = <-pool.buf:
-	case <-t.C:
-		timerPool.Put(t)
-		buf = &Buffer{cap: pool.cap}
+	case ch <- buf:
+	default:
 	}
-	return
 }
 
-// Put resets the buffer and put it to the pool
+// Put resets the buffer and returns it to the bucket matching its capacity.
 func (pool *BufferPool) Put(buf *Buffer) {
 	buf.Reset()
-	if buf.Size() > pool.maxSize {
-		return
-	}
 	if pool.Capacity() < 1 {
 		pool.cap.Reset()
 	}
-	select {
-	case pool.buf <- buf:
-	default:
+	bufCap := int64(cap(buf.internal.buf))
+	if top := pool.baseline[len(pool.baseline)-1]; bufCap > top {
+		ob := pool.overflowBucketFor(nextPowerOfTwo(bufCap))
+		pool.returnTo(ob.ch, &ob.counters, buf)
+	} else {
+		idx := pool.bucketIndex(bufCap)
+		pool.returnTo(pool.buckets[idx], &pool.counters[idx], buf)
+	}
+	pool.cond.Broadcast()
+}
+
+// Stats returns a snapshot of the allocation counters for every bucket,
+// keyed by the bucket's capacity, so callers can tune their bucket
+// baselines to their own working set. Overflow buckets, created lazily for
+// sizes beyond the largest configured tier, are included under their own
+// size class.
+func (pool *BufferPool) Stats() map[int64]Stats {
+	stats := make(map[int64]Stats, len(pool.baseline))
+	for i, baseline := range pool.baseline {
+		stats[baseline] = statsFrom(baseline, &pool.counters[i])
+	}
+	pool.overflow.Range(func(key, value interface{}) bool {
+		class := key.(int64)
+		ob := value.(*overflowBucket)
+		stats[class] = statsFrom(class, &ob.counters)
+		return true
+	})
+	return stats
+}
+
+func statsFrom(baseline int64, c *bucketCounters) Stats {
+	return Stats{
+		Baseline: baseline,
+		Get:      int64(atomic.LoadInt32(&c.get)),
+		Put:      int64(atomic.LoadInt32(&c.put)),
+		Miss:     int64(atomic.LoadInt32(&c.miss)),
+		Less:     int64(atomic.LoadInt32(&c.less)),
+		Equal:    int64(atomic.LoadInt32(&c.equal)),
+		Greater:  int64(atomic.LoadInt32(&c.greater)),
+		Half:     int64(atomic.LoadInt32(&c.half)),
 	}
 }
 
@@ -192,11 +392,32 @@ type (
 	// BufferPool represents the thread safe buffer pool.
 	// All BufferPool methods are safe for concurrent use by multiple goroutines.
 	BufferPool struct {
-		buf chan *Buffer
+		// buckets holds one channel per configured tier, ordered to match
+		// baseline. Sizes beyond the largest tier are served from overflow
+		// instead, size-classed by nextPowerOfTwo so unrelated large sizes
+		// don't share a bucket.
+		buckets  []chan *Buffer
+		baseline []int64
+		counters []bucketCounters
+		overflow sync.Map // int64 size class -> *overflowBucket
+
+		// chanSize is the channel capacity used for every bucket, regular
+		// or overflow.
+		chanSize int
 
 		// Capacity
-		maxSize int64
-		cap     *Capacity
+		cap  *Capacity
+		cond *sync.Cond
+
+		// copyBufferSize is the staging buffer size used by Copy.
+		copyBufferSize int64
+
+		// allocator manages the backing storage for buffers this pool hands out.
+		allocator Allocator
+
+		// minAlloc floors every Get request, so small writes don't each pay
+		// for a distinct undersized allocation.
+		minAlloc int64
 
 		// close
 		closeC chan struct{}
@@ -220,17 +441,36 @@ func NewBufferPool(size int64, opts *Options) *BufferPool {
 	}
 	cap.Reset()
 
+	baseline := make([]int64, bucketTiers+1)
+	for i := range baseline {
+		baseline[i] = baseline0 << uint(i)
+	}
+
+	buckets := make([]chan *Buffer, len(baseline))
+	for i := range buckets {
+		buckets[i] = make(chan *Buffer, opts.MaxPoolSize)
+	}
+
 	pool := &BufferPool{
-		buf: make(chan *Buffer, opts.MaxPoolSize),
+		buckets:  buckets,
+		baseline: baseline,
+		counters: make([]bucketCounters, len(buckets)),
+		chanSize: opts.MaxPoolSize,
 
 		// Capacity
-		maxSize: int64(size / int64(opts.MaxPoolSize)),
-		cap:     cap,
+		cap:  cap,
+		cond: sync.NewCond(cap),
+
+		copyBufferSize: opts.CopyBufferSize,
+		allocator:      opts.Allocator,
+		minAlloc:       opts.MinAlloc,
+
 		// close
 		closeC: make(chan struct{}, 1),
 	}
 
 	go pool.drain()
+	go pool.monitorMemory(opts.MemoryFraction, opts.MemStatFunc, size)
 
 	return pool
 }
@@ -305,12 +545,15 @@ func (cap *Capacity) NewTicker() *time.Timer {
 	return time.NewTimer(d)
 }
 
-// Backoff backs off buffer pool if currentInterval is greater than Backoff threshold.
+// Backoff blocks the caller while the pool is under memory pressure, i.e.
+// its capacity is at or above DefaultBackoffThreshold. It is woken by a
+// Put that frees space or by the periodic memory monitor, rather than a
+// fixed-duration timer.
 func (pool *BufferPool) Backoff() {
-	t := pool.cap.NewTicker()
-	select {
-	case <-t.C:
-		timerPool.Put(t)
+	pool.cap.Lock()
+	defer pool.cap.Unlock()
+	for pool.cap.targetSize > 0 && float64(pool.cap.size)/float64(pool.cap.targetSize) >= DefaultBackoffThreshold {
+		pool.cond.Wait()
 	}
 }
 
@@ -319,6 +562,34 @@ func (pool *BufferPool) Done() {
 	close(pool.closeC)
 }
 
+// Destroy closes the pool and releases every backing slice currently held
+// in its buckets via the configured Allocator, so off-heap memory is
+// actually freed rather than leaked when the pool is discarded. The pool
+// must not be used after Destroy.
+func (pool *BufferPool) Destroy() {
+	pool.Done()
+	drainBucket := func(bucket chan *Buffer) {
+	drain:
+		for {
+			select {
+			case buf := <-bucket:
+				if buf.internal.buf != nil {
+					pool.allocator.Free(buf.internal.buf)
+				}
+			default:
+				break drain
+			}
+		}
+	}
+	for _, bucket := range pool.buckets {
+		drainBucket(bucket)
+	}
+	pool.overflow.Range(func(_, value interface{}) bool {
+		drainBucket(value.(*overflowBucket).ch)
+		return true
+	})
+}
+
 func (pool *BufferPool) drain() {
 	ticker := time.NewTicker(30 * time.Second)
 	defer func() {
@@ -330,8 +601,20 @@ func (pool *BufferPool) drain() {
 			select {
 			case <-pool.closeC:
 				return
-			case <-pool.buf:
 			default:
+				for _, bucket := range pool.buckets {
+					select {
+					case <-bucket:
+					default:
+					}
+				}
+				pool.overflow.Range(func(_, value interface{}) bool {
+					select {
+					case <-value.(*overflowBucket).ch:
+					default:
+					}
+					return true
+				})
 			}
 		}
 	}
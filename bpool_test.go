@@ -0,0 +1,54 @@
+package bpool
+
+import (
+	"math/rand"
+	"sync"
+	"testing"
+)
+
+// TestBufferPoolStress concurrently Gets and Puts across a wide size
+// distribution and asserts the pool actually reuses buffers instead of
+// reallocating on every call.
+func TestBufferPoolStress(t *testing.T) {
+	pool := NewBufferPool(64<<20, nil)
+	defer pool.Done()
+
+	const (
+		goroutines = 16
+		iterations = 2000
+	)
+	sizes := []int64{256, 1 << 10, 3 << 10, 8 << 10, 1 << 16, 5 << 20}
+
+	var wg sync.WaitGroup
+	for g := 0; g < goroutines; g++ {
+		wg.Add(1)
+		go func(seed int64) {
+			defer wg.Done()
+			rnd := rand.New(rand.NewSource(seed))
+			for i := 0; i < iterations; i++ {
+				n := sizes[rnd.Intn(len(sizes))]
+				buf := pool.Get(n)
+				if int64(cap(buf.Internal())) < n {
+					t.Errorf("Get(%d) returned buffer with cap %d", n, cap(buf.Internal()))
+				}
+				pool.Put(buf)
+			}
+		}(int64(g) + 1)
+	}
+	wg.Wait()
+
+	var hit, miss int64
+	for _, s := range pool.Stats() {
+		hit += s.Get
+		miss += s.Miss
+	}
+	total := hit + miss
+	if total == 0 {
+		t.Fatal("expected at least one Get to reach a bucket")
+	}
+
+	const threshold = 0.9
+	if ratio := float64(hit) / float64(total); ratio < threshold {
+		t.Fatalf("hit ratio %.3f below threshold %.2f (hit=%d miss=%d)", ratio, threshold, hit, miss)
+	}
+}
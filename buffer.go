@@ -7,11 +7,41 @@ import (
 
 type (
 	buffer struct {
-		buf  []byte
-		size int64
+		buf   []byte
+		size  int64
+		alloc Allocator
+
+		// retired holds backing arrays superseded by growBy, kept around
+		// rather than freed immediately since Bytes() may have handed a
+		// caller a live alias into one. Released once that can no longer
+		// be the case, in reset (see reset's doc comment).
+		retired [][]byte
 	}
 )
 
+// growBy grows buf by diff bytes, zero-filled, via alloc when the current
+// backing array has less than diff bytes of spare capacity. alloc is nil for buffers created
+// outside of a BufferPool, in which case it falls back to a plain append.
+//
+// The old backing array is deliberately NOT freed here: Bytes() may have
+// handed a caller a live alias into it, and freeing it out from under them
+// would be a use-after-free with an off-heap Allocator. It's retired
+// instead, and released once the buffer is reset.
+func (b *buffer) growBy(diff int) {
+	if b.alloc == nil || cap(b.buf)-len(b.buf) >= diff {
+		b.buf = append(b.buf, make([]byte, diff)...)
+		return
+	}
+	old := b.buf
+	next := b.alloc.Alloc(len(old) + diff)
+	next = next[:len(old)]
+	copy(next, old)
+	b.buf = append(next, make([]byte, diff)...)
+	if old != nil {
+		b.retired = append(b.retired, old)
+	}
+}
+
 func (b *buffer) append(data []byte) (int64, error) {
 	off := b.Size()
 	if _, err := b.writeAt(data, off); err != nil {
@@ -32,10 +62,25 @@ func (b *buffer) bytes() ([]byte, error) {
 	return b.slice(0, b.Size())
 }
 
+// reset truncates the buffer back to zero length without releasing its
+// current backing array, so the capacity a bucket allocated for this buffer
+// stays available for the next Get to actually reuse. A zero-cap buffer put
+// back into a bucket is a buffer Get can never satisfy from that bucket
+// again.
+//
+// It does release any arrays retired by growBy: reset only runs once a
+// caller hands the buffer back via Put, and the pool's contract is that
+// callers don't keep Bytes()/Internal() aliases past that point, so it's
+// the first point at which freeing them is safe.
 func (b *buffer) reset() (ok bool) {
 	atomic.StoreInt64(&b.size, 0)
-	// b.buf = b.buf[:0]
-	b.buf = nil
+	if b.alloc != nil {
+		for _, r := range b.retired {
+			b.alloc.Free(r)
+		}
+		b.retired = nil
+	}
+	b.buf = b.buf[:0]
 	return true
 }
 
@@ -60,7 +105,8 @@ func (b *buffer) readAt(p []byte, off int64) (int, error) {
 func (b *buffer) writeAt(p []byte, off int64) (int, error) {
 	n := len(p)
 	if off == b.Size() {
-		b.buf = append(b.buf, p...)
+		b.growBy(n)
+		copy(b.buf[off:], p)
 		atomic.AddInt64(&b.size, int64(n))
 	} else if off+int64(n) > b.Size() {
 		panic("trying to write past EOF - undefined behavior")
@@ -73,7 +119,7 @@ func (b *buffer) writeAt(p []byte, off int64) (int, error) {
 func (b *buffer) truncate(size int64) error {
 	if size > b.Size() {
 		diff := int(size - b.Size())
-		b.buf = append(b.buf, make([]byte, diff)...)
+		b.growBy(diff)
 	} else {
 		b.buf = b.buf[:b.Size()]
 	}
@@ -0,0 +1,75 @@
+package bpool
+
+import "context"
+
+// GetContext is like Get, but a Get blocked in the memory-pressure backoff
+// on a bucket miss can be cancelled via ctx, returning ctx.Err() promptly
+// instead of waiting for pressure to clear.
+func (pool *BufferPool) GetContext(ctx context.Context, n int64) (*Buffer, error) {
+	return pool.get(n, func() error {
+		return pool.BackoffContext(ctx)
+	})
+}
+
+// BackoffContext is like Backoff, but returns ctx.Err() promptly if ctx is
+// done before the pool's capacity drops back under DefaultBackoffThreshold.
+func (pool *BufferPool) BackoffContext(ctx context.Context) error {
+	done := make(chan struct{})
+	defer close(done)
+	go func() {
+		select {
+		case <-ctx.Done():
+			// Broadcast under the lock so it can't slip in between the
+			// ctx.Err() check below and cond.Wait and be lost.
+			pool.cap.Lock()
+			pool.cond.Broadcast()
+			pool.cap.Unlock()
+		case <-done:
+		}
+	}()
+
+	pool.cap.Lock()
+	defer pool.cap.Unlock()
+	for pool.cap.targetSize > 0 && float64(pool.cap.size)/float64(pool.cap.targetSize) >= DefaultBackoffThreshold {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		pool.cond.Wait()
+	}
+	// Pressure has cleared (or never arose), so an already-done ctx doesn't
+	// fail the call.
+	return nil
+}
+
+// WriteContext is like Write, but a write blocked backing off for
+// Options.WriteBackOff can be cancelled via ctx, returning ctx.Err()
+// promptly instead of waiting out the full backoff interval.
+func (buf *Buffer) WriteContext(ctx context.Context, p []byte) (int, error) {
+	buf.Lock()
+	defer buf.Unlock()
+	if buf.cap.WriteBackOff {
+		t := buf.cap.NewTicker()
+		select {
+		case <-t.C:
+			timerPool.Put(t)
+		case <-ctx.Done():
+			if !t.Stop() {
+				<-t.C
+			}
+			timerPool.Put(t)
+			return 0, ctx.Err()
+		}
+	}
+
+	off, err := buf.internal.allocate(uint32(len(p)))
+	if err != nil {
+		return 0, err
+	}
+	if _, err := buf.internal.writeAt(p, off); err != nil {
+		return 0, err
+	}
+	buf.cap.Lock()
+	defer buf.cap.Unlock()
+	buf.cap.size += int64(len(p))
+	return len(p), nil
+}
@@ -0,0 +1,112 @@
+package bpool
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+// TestBackoffContextNoWaitIgnoresCanceledCtx checks a call that needs no
+// backoff succeeds even with an already-canceled ctx: BackoffContext must
+// only consult ctx.Err() for the pressure it actually waited out.
+func TestBackoffContextNoWaitIgnoresCanceledCtx(t *testing.T) {
+	pool := NewBufferPool(64<<20, nil)
+	defer pool.Done()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	if err := pool.BackoffContext(ctx); err != nil {
+		t.Fatalf("BackoffContext returned %v for a pool under no pressure", err)
+	}
+}
+
+// TestBackoffContextCancelUnblocks checks a BackoffContext call parked under
+// pressure returns ctx.Err() promptly once ctx is canceled.
+func TestBackoffContextCancelUnblocks(t *testing.T) {
+	pool := NewBufferPool(int64(len("x")), nil)
+	defer pool.Done()
+
+	pool.cap.Lock()
+	pool.cap.size = pool.cap.targetSize
+	pool.cap.Unlock()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	errc := make(chan error, 1)
+	go func() { errc <- pool.BackoffContext(ctx) }()
+
+	select {
+	case err := <-errc:
+		t.Fatalf("BackoffContext returned %v before cancellation", err)
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	cancel()
+
+	select {
+	case err := <-errc:
+		if err != context.Canceled {
+			t.Fatalf("BackoffContext returned %v, want context.Canceled", err)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("BackoffContext did not unblock after cancellation")
+	}
+}
+
+// TestGetContext checks GetContext serves a buffer like Get when ctx is
+// never canceled.
+func TestGetContext(t *testing.T) {
+	pool := NewBufferPool(64<<20, nil)
+	defer pool.Done()
+
+	buf, err := pool.GetContext(context.Background(), baseline0)
+	if err != nil {
+		t.Fatalf("GetContext: %v", err)
+	}
+	if int64(cap(buf.Internal())) < baseline0 {
+		t.Fatalf("GetContext returned buffer with cap %d", cap(buf.Internal()))
+	}
+}
+
+// TestWriteContextCancelUnblocks checks WriteContext returns ctx.Err()
+// promptly when ctx is canceled during the write backoff wait instead of
+// waiting out the full backoff interval.
+func TestWriteContextCancelUnblocks(t *testing.T) {
+	pool := NewBufferPool(64<<20, &Options{
+		WriteBackOff:    true,
+		MaxElapsedTime:  time.Minute,
+		InitialInterval: time.Minute,
+	})
+	defer pool.Done()
+
+	buf := pool.Get(baseline0)
+
+	pool.cap.Lock()
+	pool.cap.size = pool.cap.targetSize
+	pool.cap.Unlock()
+
+	ctx, cancel := context.WithCancel(context.Background())
+
+	errc := make(chan error, 1)
+	go func() {
+		_, err := buf.WriteContext(ctx, []byte("x"))
+		errc <- err
+	}()
+
+	select {
+	case err := <-errc:
+		t.Fatalf("WriteContext returned %v before cancellation", err)
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	cancel()
+
+	select {
+	case err := <-errc:
+		if err != context.Canceled {
+			t.Fatalf("WriteContext returned %v, want context.Canceled", err)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("WriteContext did not unblock after cancellation")
+	}
+}
@@ -0,0 +1,40 @@
+package bpool
+
+import (
+	"io"
+	"os"
+)
+
+// Copier copies from src to dst using a pooled buffer, in place of io.Copy.
+type Copier interface {
+	Copy(dst io.Writer, src io.Reader) (int64, error)
+}
+
+// Copy copies from src to dst using a buffer checked out of the pool,
+// sized to Options.CopyBufferSize, and returns it to the pool when done.
+// It behaves like io.Copy, reusing pooled memory instead of allocating a
+// fresh buffer on every call. As with io.CopyBuffer, the pooled buffer goes
+// unused when src implements io.WriterTo or dst implements io.ReaderFrom.
+func (pool *BufferPool) Copy(dst io.Writer, src io.Reader) (int64, error) {
+	buf := pool.Get(pool.copyBufferSize)
+	defer pool.Put(buf)
+	return pool.CopyBuffer(dst, src, buf)
+}
+
+// CopyBuffer is like Copy but uses buf as the staging buffer instead of
+// checking out one of its own. The caller remains responsible for buf,
+// including returning it to the pool via Put on every path, panic included.
+func (pool *BufferPool) CopyBuffer(dst io.Writer, src io.Reader, buf *Buffer) (written int64, err error) {
+	p := buf.Internal()
+	if cap(p) == 0 {
+		p = make([]byte, pool.copyBufferSize)
+	}
+	p = p[:cap(p)]
+	return io.CopyBuffer(dst, src, p)
+}
+
+// defaultCopyBufferSize is the staging buffer size used by Copy when
+// Options.CopyBufferSize is left unset.
+func defaultCopyBufferSize() int64 {
+	return int64(16 * os.Getpagesize())
+}
@@ -0,0 +1,70 @@
+package bpool
+
+import (
+	"bytes"
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestCopy(t *testing.T) {
+	pool := NewBufferPool(64<<20, nil)
+	defer pool.Done()
+
+	src := strings.Repeat("hello bpool", 1000)
+	var dst bytes.Buffer
+
+	n, err := pool.Copy(&dst, strings.NewReader(src))
+	if err != nil {
+		t.Fatalf("Copy: %v", err)
+	}
+	if n != int64(len(src)) {
+		t.Fatalf("Copy returned %d, want %d", n, len(src))
+	}
+	if dst.String() != src {
+		t.Fatal("Copy produced wrong output")
+	}
+}
+
+// errReader fails after n reads, to drive CopyBuffer down an error path.
+type errReader struct{ n int }
+
+func (r *errReader) Read(p []byte) (int, error) {
+	if r.n == 0 {
+		return 0, errors.New("boom")
+	}
+	r.n--
+	p[0] = 'x'
+	return 1, nil
+}
+
+// TestCopyBufferLeavesBufToCallerOnError checks CopyBuffer doesn't Put buf
+// itself on an error path: after the caller's own Put, the put count
+// matches the served count.
+func TestCopyBufferLeavesBufToCallerOnError(t *testing.T) {
+	pool := NewBufferPool(64<<20, nil)
+	defer pool.Done()
+
+	buf := pool.Get(1024)
+	_, err := pool.CopyBuffer(&bytes.Buffer{}, &errReader{n: 2}, buf)
+	if err == nil {
+		t.Fatal("expected an error from the failing reader")
+	}
+	pool.Put(buf)
+
+	if stats := sumStats(pool); stats.put != stats.get+stats.miss {
+		t.Fatalf("Put count %d does not match served count %d after CopyBuffer error", stats.put, stats.get+stats.miss)
+	}
+}
+
+type totals struct{ get, put, miss int64 }
+
+func sumStats(pool *BufferPool) totals {
+	var t totals
+	for _, s := range pool.Stats() {
+		t.get += s.Get
+		t.put += s.Put
+		t.miss += s.Miss
+	}
+	return t
+}
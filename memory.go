@@ -0,0 +1,69 @@
+package bpool
+
+import (
+	"runtime"
+	"runtime/debug"
+	"time"
+)
+
+// MemStatFunc reports total and free system memory, in bytes. The default
+// (see readMemStats) only sees this process's own Go runtime footprint, not
+// true host-wide free memory; wire in a real source such as gopsutil for
+// target size recomputation that actually tracks system memory pressure.
+type MemStatFunc func() (total, free uint64)
+
+// readMemStats is the default MemStatFunc when Options.MemStatFunc is left
+// unset. It is NOT a measure of free system memory: runtime.MemStats.Sys is
+// memory this process's Go runtime has already obtained from the OS, so
+// this only approximates this process's own unused headroom within that,
+// as Sys minus the heap currently in use. It drifts further from real
+// system headroom as the heap grows, and says nothing about memory other
+// processes are using. Treat it as a rough self-pressure signal, not a
+// true reading of available system memory.
+func readMemStats() (total, free uint64) {
+	var m runtime.MemStats
+	runtime.ReadMemStats(&m)
+	if m.Sys < m.HeapInuse {
+		return m.Sys, 0
+	}
+	return m.Sys, m.Sys - m.HeapInuse
+}
+
+// monitorMemory periodically recomputes the pool's target size from the
+// memory sampled by memStat (see readMemStats for what the default
+// measures), so the pool backs off before Go heap growth turns into GC
+// pressure elsewhere in the process. It runs for the lifetime of the pool,
+// alongside drain, and is a no-op when fraction is unset.
+func (pool *BufferPool) monitorMemory(fraction float64, memStat MemStatFunc, configured int64) {
+	if fraction <= 0 {
+		return
+	}
+	if memStat == nil {
+		memStat = readMemStats
+	}
+
+	ticker := time.NewTicker(30 * time.Second)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-pool.closeC:
+			return
+		case <-ticker.C:
+			_, free := memStat()
+			target := int64(fraction * float64(free))
+			if target <= 0 || target > configured {
+				target = configured
+			}
+
+			pool.cap.Lock()
+			pool.cap.targetSize = target
+			pressure := float64(pool.cap.size)/float64(target) >= DefaultBackoffThreshold
+			pool.cap.Unlock()
+
+			if pressure {
+				debug.FreeOSMemory()
+			}
+			pool.cond.Broadcast()
+		}
+	}
+}
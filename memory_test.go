@@ -0,0 +1,72 @@
+package bpool
+
+import (
+	"testing"
+	"time"
+)
+
+// TestReadMemStats checks the default MemStatFunc returns numbers that are
+// at least internally consistent: free never exceeds total.
+func TestReadMemStats(t *testing.T) {
+	total, free := readMemStats()
+	if total == 0 {
+		t.Fatal("expected a non-zero Sys reading")
+	}
+	if free > total {
+		t.Fatalf("free (%d) exceeds total (%d)", free, total)
+	}
+}
+
+// TestMonitorMemoryNoopWithoutFraction checks monitorMemory returns
+// immediately, without starting its ticker loop, when no MemoryFraction is
+// configured.
+func TestMonitorMemoryNoopWithoutFraction(t *testing.T) {
+	pool := &BufferPool{cap: &Capacity{}}
+
+	done := make(chan struct{})
+	go func() {
+		pool.monitorMemory(0, nil, 100)
+		close(done)
+	}()
+
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("monitorMemory did not return immediately with fraction <= 0")
+	}
+}
+
+// TestBackoffUnblocksOnPut checks a caller parked in Backoff under pressure
+// is woken once capacity drops back under DefaultBackoffThreshold and the
+// pool broadcasts (as Put does).
+func TestBackoffUnblocksOnPut(t *testing.T) {
+	pool := NewBufferPool(int64(len("x")), nil)
+	defer pool.Done()
+
+	pool.cap.Lock()
+	pool.cap.size = pool.cap.targetSize
+	pool.cap.Unlock()
+
+	done := make(chan struct{})
+	go func() {
+		pool.Backoff()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		t.Fatal("Backoff returned before any pressure relief")
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	pool.cap.Lock()
+	pool.cap.size = 0
+	pool.cap.Unlock()
+	pool.cond.Broadcast()
+
+	select {
+	case <-done:
+	case <-time.After(2 * time.Second):
+		t.Fatal("Backoff did not unblock after pressure cleared")
+	}
+}
@@ -20,6 +20,39 @@ type Options struct {
 
 	// WriteBackOff to turn on Backoff for buffer writes
 	WriteBackOff bool
+
+	// CopyBufferSize sets the size of the staging buffer used by
+	// BufferPool.Copy. Defaults to 16 * os.Getpagesize().
+	CopyBufferSize int64
+
+	// MemoryFraction, if non-zero, derives the pool's target size from
+	// sampled memory instead of the fixed size passed to NewBufferPool:
+	// targetSize = min(configured size, MemoryFraction*available), or the
+	// configured size when MemoryFraction*available is zero. Recomputed
+	// periodically by a background goroutine. Without a MemStatFunc, the
+	// "available" sample is only a rough self-pressure heuristic, not true
+	// system-wide free memory — see MemStatFunc.
+	MemoryFraction float64
+
+	// MemStatFunc overrides how available memory is sampled when
+	// MemoryFraction is set. Defaults to a process-local runtime.MemStats
+	// heuristic; supply a real source (e.g. gopsutil) to track actual
+	// system memory pressure.
+	MemStatFunc MemStatFunc
+
+	// Allocator manages the backing storage for pooled buffers. Defaults to
+	// the Go heap; plug in a cgo-backed Allocator to keep large, long-lived
+	// buffers off the Go heap and out of GC scan.
+	Allocator Allocator
+
+	// MinAlloc floors every Get request, in bytes. Defaults to
+	// DefaultMinAlloc (1KiB), and smaller values are raised to it: every
+	// regular bucket allocates
+	// buffers at exactly its own baseline capacity, and baseline0 is that
+	// baseline's floor, so a smaller MinAlloc can never be satisfied by an
+	// exact match and would make Get's oversized-buffer check misfire on
+	// every call instead of helping.
+	MinAlloc int64
 }
 
 func (src *Options) copyWithDefaults() *Options {
@@ -44,5 +77,17 @@ func (src *Options) copyWithDefaults() *Options {
 		opts.MaxElapsedTime = DefaultMaxElapsedTime
 	}
 
+	if opts.CopyBufferSize == 0 {
+		opts.CopyBufferSize = defaultCopyBufferSize()
+	}
+
+	if opts.Allocator == nil {
+		opts.Allocator = heapAllocator{}
+	}
+
+	if opts.MinAlloc < DefaultMinAlloc {
+		opts.MinAlloc = DefaultMinAlloc
+	}
+
 	return &opts
 }